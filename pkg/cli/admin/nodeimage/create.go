@@ -3,13 +3,19 @@ package nodeimage
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -20,7 +26,10 @@ import (
 	kapierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage/names"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/genericiooptions"
 	"k8s.io/client-go/kubernetes"
@@ -32,7 +41,6 @@ import (
 	"sigs.k8s.io/yaml"
 
 	configclient "github.com/openshift/client-go/config/clientset/versioned"
-	"github.com/openshift/library-go/pkg/operator/resource/retry"
 	ocrelease "github.com/openshift/oc/pkg/cli/admin/release"
 	imagemanifest "github.com/openshift/oc/pkg/cli/image/manifest"
 	"github.com/openshift/oc/pkg/cli/rsync"
@@ -41,8 +49,42 @@ import (
 const (
 	nodeJoinerConfigurationFile = "nodes-config.yaml"
 	nodeJoinerContainer         = "node-joiner"
+	bootArtifactsDir            = "boot-artifacts"
+
+	formatISO  = "iso"
+	formatPXE  = "pxe"
+	formatBoth = "both"
+
+	nodeJoinerAppLabel        = "app"
+	nodeJoinerAppLabelValue   = "node-joiner"
+	nodeJoinerConfigHashLabel = "node-image.openshift.io/config-hash"
+
+	// nodeJoinerServiceAccountName is the fixed ServiceAccount name used
+	// whenever o.Namespace pins the namespace to a fixed name, so that a
+	// later --skip-rbac run targets the very same ServiceAccount a prior
+	// --dry-run=client run rendered (and a platform admin applied).
+	nodeJoinerServiceAccountName = "node-joiner"
 )
 
+// goArchToNodeJoinerArch maps the Go/node architecture naming (as reported by
+// Node.Status.NodeInfo.Architecture) to the architecture naming expected by
+// the node-joiner CLI tool and used in the generated ISO file names.
+var goArchToNodeJoinerArch = map[string]string{
+	"amd64":   "x86_64",
+	"arm64":   "aarch64",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+}
+
+// supportedNodeJoinerArches is the set of architectures the baremetal-installer
+// image (and therefore node-joiner) is known to ship ISOs for.
+var supportedNodeJoinerArches = map[string]bool{
+	"x86_64":  true,
+	"aarch64": true,
+	"ppc64le": true,
+	"s390x":   true,
+}
+
 var (
 	createLong = templates.LongDesc(`
 		Create an ISO image from an initial configuration for a given set of nodes, 
@@ -70,6 +112,30 @@ var (
 
 		# Specify a custom image name
 		  oc adm node-image create --o=my-node.iso
+
+		# Create ISO images for both x86_64 and aarch64 nodes
+		  oc adm node-image create --arch=x86_64 --arch=aarch64
+
+		# Generate PXE boot artifacts instead of an ISO
+		  oc adm node-image create --format=pxe
+
+		# Generate PXE boot artifacts and serve them over HTTP for the DHCP/next-server config
+		  oc adm node-image create --format=pxe --serve-http=:8080
+
+		# Render the Namespace, RBAC, ConfigMap and Pod manifests instead of creating them, for a
+		# platform admin to review and apply out-of-band
+		  oc adm node-image create --dry-run=client --namespace=node-joiner
+
+		# Validate the created resources against the API server without persisting them
+		  oc adm node-image create --dry-run=server
+
+		# Run against the namespace and RBAC a platform admin already applied out-of-band
+		# from the --dry-run=client run above
+		  oc adm node-image create --skip-rbac --namespace=node-joiner
+
+		# Keep the namespace around on completion, so a later run with an unchanged
+		# nodes-config.yaml can reuse it instead of generating the artifacts again
+		  oc adm node-image create --keep-namespace
 	`)
 )
 
@@ -115,6 +181,44 @@ type CreateOptions struct {
 	AssetsDir string
 	// OutputName allows the user to specify the name of the generated image.
 	OutputName string
+	// Architectures allows the user to specify one or more target architectures
+	// to generate the ISO image for. Defaults to the architecture of the target
+	// cluster.
+	Architectures []string
+	// Format selects the kind of artifacts to generate: iso, pxe or both.
+	Format string
+	// ServeHTTP, when set, makes the command start a short-lived HTTP server
+	// on the given address, rooted at AssetsDir, once the artifacts have been
+	// copied locally.
+	ServeHTTP string
+	// FollowLogs streams the node-joiner container logs as the image is being
+	// generated, instead of only dumping them on failure.
+	FollowLogs bool
+	// DryRunStrategy controls whether the Namespace, ServiceAccount,
+	// ClusterRole, ClusterRoleBinding, ConfigMap and Pod created by
+	// runNodeJoinerPod are actually persisted: "client" renders them as a
+	// YAML manifest stream instead of calling the API, "server" submits them
+	// with DryRun: []string{"All"} so the API server validates them without
+	// persisting anything.
+	DryRunStrategy kcmdutil.DryRunStrategy
+	// Namespace pins the namespace created by runNodeJoinerPod (and the
+	// ServiceAccount/ClusterRole/ClusterRoleBinding names derived from it) to
+	// a fixed, user-chosen name instead of letting the API server generate
+	// one. Required by SkipRBAC, since the resources a --dry-run=client run
+	// rendered for a platform admin to apply are only reachable by name, in
+	// a namespace that's still around on the next invocation.
+	Namespace string
+	// SkipRBAC skips (re-)creating the ServiceAccount, ClusterRole and
+	// ClusterRoleBinding, for use on a namespace where a platform admin
+	// already applied them out-of-band from a previous --dry-run=client run.
+	SkipRBAC bool
+	// Force always starts a fresh run, ignoring any existing namespace from a
+	// prior run whose configuration hash matches.
+	Force bool
+	// KeepNamespace leaves the namespace (and the generated artifacts it
+	// holds) in place after completion, instead of deleting it, so a later
+	// run with an unchanged configuration can reuse it.
+	KeepNamespace bool
 
 	RESTClientGetter         genericclioptions.RESTClientGetter
 	nodeJoinerImage          string
@@ -124,6 +228,9 @@ type CreateOptions struct {
 	nodeJoinerPod            *corev1.Pod
 	nodeJoinerExitCode       int
 	rsyncRshCmd              string
+	logsStreamed             bool
+	releaseImage             string
+	configHash               string
 }
 
 // AddFlags defined the required command flags.
@@ -133,6 +240,15 @@ func (o *CreateOptions) AddFlags(cmd *cobra.Command) {
 
 	flags.StringVar(&o.AssetsDir, "dir", o.AssetsDir, "The path containing the configuration file, used also to store the generated artifacts.")
 	flags.StringVarP(&o.OutputName, "output-name", "o", "node.iso", "The name of the output image.")
+	flags.StringSliceVar(&o.Architectures, "arch", o.Architectures, "The target architecture(s) of the nodes to add, e.g. x86_64, aarch64, ppc64le, s390x. Can be specified multiple times. Defaults to the target cluster's own architecture.")
+	flags.StringVar(&o.Format, "format", formatISO, "The artifacts to generate. One of: iso|pxe|both.")
+	flags.StringVar(&o.ServeHTTP, "serve-http", o.ServeHTTP, "After copying the artifacts locally, serve AssetsDir over HTTP on the given address (e.g. :8080), so a DHCP/next-server config can reach them directly.")
+	flags.BoolVar(&o.FollowLogs, "follow-logs", o.FollowLogs, "Stream the node-joiner container logs while the image is being generated.")
+	flags.StringVar(&o.Namespace, "namespace", o.Namespace, "Pin the namespace (and the names of the ServiceAccount/ClusterRole/ClusterRoleBinding derived from it) to a fixed name instead of generating one. Required when --skip-rbac is set.")
+	flags.BoolVar(&o.SkipRBAC, "skip-rbac", o.SkipRBAC, "Skip creating the ServiceAccount, ClusterRole and ClusterRoleBinding, assuming they were already applied out-of-band from a --dry-run=client run. Requires --namespace.")
+	flags.BoolVar(&o.Force, "force", o.Force, "Always start a fresh run, ignoring any existing namespace from a prior run with a matching configuration.")
+	flags.BoolVar(&o.KeepNamespace, "keep-namespace", o.KeepNamespace, "Do not delete the namespace on completion, so a later run with an unchanged configuration can reuse it, and so the generated artifacts remain fetchable from the cluster.")
+	kcmdutil.AddDryRunFlag(cmd)
 }
 
 // Complete completes the required options for the create command.
@@ -149,6 +265,9 @@ func (o *CreateOptions) Complete(f kcmdutil.Factory, cmd *cobra.Command, args []
 	if o.ConfigClient, err = configclient.NewForConfig(o.Config); err != nil {
 		return err
 	}
+	if o.DryRunStrategy, err = kcmdutil.GetDryRunStrategy(cmd); err != nil {
+		return err
+	}
 
 	if o.AssetsDir == "" {
 		cwd, err := os.Getwd()
@@ -163,9 +282,36 @@ func (o *CreateOptions) Complete(f kcmdutil.Factory, cmd *cobra.Command, args []
 	o.copyStrategy = func(o *rsync.RsyncOptions) rsync.CopyStrategy {
 		return rsync.NewDefaultCopyStrategy(o)
 	}
+
+	if len(o.Architectures) == 0 {
+		arch, err := o.defaultArchitecture(context.Background())
+		if err != nil {
+			return err
+		}
+		o.Architectures = []string{arch}
+	}
 	return nil
 }
 
+// defaultArchitecture derives the target architecture to use when --arch is
+// not specified, from the architecture of an existing node in the target
+// cluster.
+func (o *CreateOptions) defaultArchitecture(ctx context.Context) (string, error) {
+	nodes, err := o.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return "", fmt.Errorf("cannot determine the target cluster architecture: %w", err)
+	}
+	if len(nodes.Items) == 0 {
+		return "", fmt.Errorf("cannot determine the target cluster architecture: no nodes found")
+	}
+	goArch := nodes.Items[0].Status.NodeInfo.Architecture
+	arch, ok := goArchToNodeJoinerArch[goArch]
+	if !ok {
+		return "", fmt.Errorf("unsupported cluster architecture %q", goArch)
+	}
+	return arch, nil
+}
+
 // Validate returns validation errors related to the create command.
 func (o *CreateOptions) Validate() error {
 	err := o.validateConfigFile()
@@ -177,9 +323,33 @@ func (o *CreateOptions) Validate() error {
 		return fmt.Errorf("--output-name cannot be empty")
 	}
 
+	for _, arch := range o.Architectures {
+		if !supportedNodeJoinerArches[arch] {
+			return fmt.Errorf("unsupported --arch %q: must be one of x86_64, aarch64, ppc64le, s390x", arch)
+		}
+	}
+
+	switch o.Format {
+	case formatISO, formatPXE, formatBoth:
+	default:
+		return fmt.Errorf("invalid --format %q: must be one of iso|pxe|both", o.Format)
+	}
+
+	if o.SkipRBAC && o.Namespace == "" {
+		return fmt.Errorf("--namespace must be set when --skip-rbac is used, so the command can reach the ServiceAccount and RBAC a platform admin already applied out-of-band")
+	}
+
 	return nil
 }
 
+func (o *CreateOptions) wantsISO() bool {
+	return o.Format == formatISO || o.Format == formatBoth
+}
+
+func (o *CreateOptions) wantsPXE() bool {
+	return o.Format == formatPXE || o.Format == formatBoth
+}
+
 func (o *CreateOptions) validateConfigFile() error {
 	// Check if configuration file exists
 	fi, err := fs.Stat(o.FSys, nodeJoinerConfigurationFile)
@@ -206,33 +376,89 @@ func (o *CreateOptions) Run() error {
 	ctx := context.Background()
 	defer o.cleanup(ctx)
 
-	err := o.runNodeJoinerPod(ctx)
-	if err != nil {
+	if err := o.computeConfigHash(ctx); err != nil {
 		return err
 	}
 
-	err = o.waitForCompletion(ctx)
+	reused, err := o.findReusableRun(ctx)
 	if err != nil {
 		return err
 	}
-	// Something went wrong during the node-joiner tool execution,
-	// let's show the logs and return an error
-	if o.nodeJoinerExitCode != 0 {
-		err = o.printLogsInPod(ctx)
-		if err != nil {
+
+	if !reused {
+		if err := o.runNodeJoinerPod(ctx); err != nil {
+			return err
+		}
+		if o.DryRunStrategy != kcmdutil.DryRunNone {
+			klog.V(1).Info("Command successfully completed (dry run)")
+			return nil
+		}
+
+		if err := o.waitForCompletion(ctx); err != nil {
 			return err
 		}
-		return fmt.Errorf("image generation error (exit code: %d)", o.nodeJoinerExitCode)
+		// Something went wrong during the node-joiner tool execution,
+		// let's show the logs and return an error. If we were already streaming
+		// the logs live, they've already been shown: don't dump them again.
+		if o.nodeJoinerExitCode != 0 {
+			if !o.logsStreamed {
+				if err := o.printLogsInPod(ctx); err != nil {
+					return err
+				}
+			}
+			return fmt.Errorf("image generation error (exit code: %d)", o.nodeJoinerExitCode)
+		}
 	}
 
-	err = o.copyArtifactsFromNodeJoinerPod()
-	if err != nil {
+	if err := o.copyArtifactsFromNodeJoinerPod(); err != nil {
 		return err
 	}
 	klog.V(1).Info("Command successfully completed")
+
+	if o.ServeHTTP != "" {
+		// The artifacts are already copied to local disk at this point, so
+		// there's no reason to keep the namespace/pod/RBAC around on the
+		// cluster for serveArtifacts' (potentially long) blocking HTTP serve:
+		// clean up now rather than leaving the deferred cleanup to fire only
+		// once serveArtifacts returns.
+		o.cleanup(ctx)
+		return o.serveArtifacts()
+	}
 	return nil
 }
 
+// serveArtifacts starts an HTTP server rooted at AssetsDir, so that a
+// DHCP/next-server configuration can fetch the generated artifacts directly.
+// It blocks until interrupted.
+func (o *CreateOptions) serveArtifacts() error {
+	server := &http.Server{
+		Addr:    o.ServeHTTP,
+		Handler: http.FileServer(http.Dir(o.AssetsDir)),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	fmt.Fprintf(o.IOStreams.Out, "Serving %s on %s, press Ctrl+C to stop\n", o.AssetsDir, o.ServeHTTP)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-errCh:
+		if err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	case <-sigCh:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(ctx)
+	}
+}
+
 func (o *CreateOptions) printLogsInPod(ctx context.Context) error {
 	logOptions := &corev1.PodLogOptions{
 		Container:  nodeJoinerContainer,
@@ -248,13 +474,92 @@ func (o *CreateOptions) printLogsInPod(ctx context.Context) error {
 	return err
 }
 
+// shouldFollowLogs reports whether the node-joiner container logs should be
+// streamed live, either because --follow-logs was set or because the user
+// asked for -v=2 or above.
+func (o *CreateOptions) shouldFollowLogs() bool {
+	return o.FollowLogs || klog.V(2).Enabled()
+}
+
+// streamPodLogs pumps the node-joiner container logs into o.IOStreams.Out
+// until ctx is cancelled, restarting the stream on transient API errors.
+func (o *CreateOptions) streamPodLogs(ctx context.Context) {
+	for {
+		err := o.streamPodLogsOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			klog.V(2).Infof("log stream interrupted, retrying: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (o *CreateOptions) streamPodLogsOnce(ctx context.Context) error {
+	logOptions := &corev1.PodLogOptions{
+		Container:  nodeJoinerContainer,
+		Timestamps: true,
+		Follow:     true,
+	}
+	readCloser, err := o.Client.CoreV1().Pods(o.nodeJoinerNamespace.GetName()).GetLogs(o.nodeJoinerPod.GetName(), logOptions).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer readCloser.Close()
+
+	_, err = io.Copy(o.IOStreams.Out, readCloser)
+	return err
+}
+
 func (o *CreateOptions) copyArtifactsFromNodeJoinerPod() error {
 	klog.V(2).Infof("Copying artifacts from %s", o.nodeJoinerPod.GetName())
+
+	if o.wantsISO() {
+		if err := o.copyISOArtifacts(); err != nil {
+			return err
+		}
+	}
+	if o.wantsPXE() {
+		if err := o.rsyncFromPod(path.Join("/assets", bootArtifactsDir)+"/", path.Join(o.AssetsDir, bootArtifactsDir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *CreateOptions) copyISOArtifacts() error {
+	// A single architecture keeps the historical behavior of copying the
+	// generated ISO to the user-specified --output-name.
+	if len(o.Architectures) <= 1 {
+		name := fmt.Sprintf("node.%s.iso", o.Architectures[0])
+		return o.rsyncFromPod(path.Join("/assets", name), path.Join(o.AssetsDir, o.OutputName))
+	}
+
+	// Multiple architectures each produce their own node.<arch>.iso, so they
+	// can't all be renamed to a single --output-name: keep their own names.
+	for arch := range supportedNodeJoinerArches {
+		if !containsArch(o.Architectures, arch) {
+			continue
+		}
+		name := fmt.Sprintf("node.%s.iso", arch)
+		if err := o.rsyncFromPod(path.Join("/assets", name), path.Join(o.AssetsDir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *CreateOptions) rsyncFromPod(sourcePath, destPath string) error {
 	rsyncOptions := &rsync.RsyncOptions{
 		Namespace:     o.nodeJoinerNamespace.GetName(),
-		Source:        &rsync.PathSpec{PodName: o.nodeJoinerPod.GetName(), Path: path.Join("/assets", "node.x86_64.iso")},
+		Source:        &rsync.PathSpec{PodName: o.nodeJoinerPod.GetName(), Path: sourcePath},
 		ContainerName: nodeJoinerContainer,
-		Destination:   &rsync.PathSpec{PodName: "", Path: path.Join(o.AssetsDir, o.OutputName)},
+		Destination:   &rsync.PathSpec{PodName: "", Path: destPath},
 		Client:        o.Client,
 		Config:        o.Config,
 		Compress:      true,
@@ -266,106 +571,190 @@ func (o *CreateOptions) copyArtifactsFromNodeJoinerPod() error {
 	return rsyncOptions.RunRsync()
 }
 
+func containsArch(arches []string, arch string) bool {
+	for _, a := range arches {
+		if a == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// podPhase is the set of states the node-joiner pod transitions through
+// while waitForCompletion tracks it.
+type podPhase string
+
+const (
+	podPhasePending         podPhase = "Pending"
+	podPhaseImagePullFailed podPhase = "ImagePullFailed"
+	podPhaseRunning         podPhase = "Running"
+	podPhaseTerminated      podPhase = "Terminated"
+)
+
 func (o *CreateOptions) waitForCompletion(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute*5)
+	defer cancel()
+
 	klog.V(2).Infof("Starting command in pod %s", o.nodeJoinerPod.GetName())
-	// Wait for the node-joiner pod to come up
-	err := wait.PollUntilContextTimeout(
-		ctx,
-		time.Second*1,
-		time.Minute*5,
-		true,
-		func(ctx context.Context) (done bool, err error) {
-			pod, err := o.Client.CoreV1().Pods(o.nodeJoinerNamespace.GetName()).Get(ctx, o.nodeJoinerPod.GetName(), metav1.GetOptions{})
-			if err == nil {
-				klog.V(2).Info("Waiting for pod")
-				if len(pod.Status.ContainerStatuses) == 0 {
-					return false, nil
-				}
-				state := pod.Status.ContainerStatuses[0].State
-				if state.Waiting != nil {
-					switch state.Waiting.Reason {
-					case "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
-						return true, fmt.Errorf("unable to pull image: %v: %v", state.Waiting.Reason, state.Waiting.Message)
-					}
-				}
-				return state.Running != nil || state.Terminated != nil, nil
-			}
-			if retry.IsHTTPClientError(err) {
-				return false, nil
-			}
-			return false, err
-		})
-	if err != nil {
+	if err := o.waitForPodRunning(ctx); err != nil {
 		return err
 	}
 
-	// Wait for the node-joiner cli tool to complete
-	return wait.PollUntilContextTimeout(
-		ctx,
-		time.Second*5,
-		time.Minute*5,
-		true,
-		func(ctx context.Context) (done bool, err error) {
-			w := &bytes.Buffer{}
-			wErr := &bytes.Buffer{}
-
-			execOptions := &exec.ExecOptions{
-				StreamOptions: exec.StreamOptions{
-					Namespace:     o.nodeJoinerNamespace.GetName(),
-					PodName:       o.nodeJoinerPod.GetName(),
-					ContainerName: nodeJoinerContainer,
-					IOStreams: genericiooptions.IOStreams{
-						In:     nil,
-						Out:    w,
-						ErrOut: wErr,
-					},
-					Stdin: false,
-					Quiet: false,
-				},
-				Executor:  o.remoteExecutor,
-				PodClient: o.Client.CoreV1(),
-				Config:    o.Config,
-				Command: []string{
-					"cat", "/assets/exit_code",
-				},
-			}
+	if o.shouldFollowLogs() {
+		logsCtx, cancelLogs := context.WithCancel(ctx)
+		defer cancelLogs()
+		o.logsStreamed = true
+		go o.streamPodLogs(logsCtx)
+	}
 
-			err = execOptions.Validate()
-			if err != nil {
-				return false, err
-			}
+	return o.waitForExitCode(ctx)
+}
 
-			klog.V(1).Info("Image generation in progress, please wait")
-			err = execOptions.Run()
+// waitForPodRunning watches the node-joiner pod until its container reaches
+// the Running (or Terminated) state, failing fast on an image pull error.
+func (o *CreateOptions) waitForPodRunning(ctx context.Context) error {
+	w, err := o.Client.CoreV1().Pods(o.nodeJoinerNamespace.GetName()).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", o.nodeJoinerPod.GetName()),
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for pod %s to start: %w", o.nodeJoinerPod.GetName(), ctx.Err())
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("pod watch for %s closed unexpectedly", o.nodeJoinerPod.GetName())
+			}
+			pod, ok := event.Object.(*corev1.Pod)
+			if !ok || event.Type == watch.Deleted {
+				continue
+			}
+			phase, err := containerPhase(pod)
 			if err != nil {
-				var codeExitErr kutils.CodeExitError
-				if !errors.As(err, &codeExitErr) {
-					return false, err
-				}
-				if codeExitErr.Code != 1 {
-					return false, fmt.Errorf("unexpected error code: %w", codeExitErr)
-				}
-				return false, nil
+				return err
+			}
+			klog.V(2).Infof("Waiting for pod, phase: %s", phase)
+			if phase == podPhaseRunning || phase == podPhaseTerminated {
+				return nil
 			}
+		}
+	}
+}
 
-			// Extract node-joiner tool exit code on completion
-			o.nodeJoinerExitCode, err = strconv.Atoi(w.String())
-			if err != nil {
-				return false, err
+// containerPhase classifies the state of the node-joiner pod's single
+// container into one of the podPhase states.
+func containerPhase(pod *corev1.Pod) (podPhase, error) {
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return podPhasePending, nil
+	}
+	state := pod.Status.ContainerStatuses[0].State
+	if state.Waiting != nil {
+		switch state.Waiting.Reason {
+		case "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+			return podPhaseImagePullFailed, fmt.Errorf("unable to pull image: %v: %v", state.Waiting.Reason, state.Waiting.Message)
+		}
+		return podPhasePending, nil
+	}
+	if state.Terminated != nil {
+		return podPhaseTerminated, nil
+	}
+	if state.Running != nil {
+		return podPhaseRunning, nil
+	}
+	return podPhasePending, nil
+}
+
+// waitForExitCode opens a single long-lived exec session that tails
+// /assets/exit_code inside the node-joiner container, so that completion is
+// delivered event-driven instead of by re-exec-ing on a timer.
+func (o *CreateOptions) waitForExitCode(ctx context.Context) error {
+	w := &bytes.Buffer{}
+	wErr := &bytes.Buffer{}
+
+	execOptions := &exec.ExecOptions{
+		StreamOptions: exec.StreamOptions{
+			Namespace:     o.nodeJoinerNamespace.GetName(),
+			PodName:       o.nodeJoinerPod.GetName(),
+			ContainerName: nodeJoinerContainer,
+			IOStreams: genericiooptions.IOStreams{
+				In:     nil,
+				Out:    w,
+				ErrOut: wErr,
+			},
+			Stdin: false,
+			Quiet: false,
+		},
+		Executor:  o.remoteExecutor,
+		PodClient: o.Client.CoreV1(),
+		Config:    o.Config,
+		Command: []string{
+			"/bin/sh", "-c", "tail -F /assets/exit_code 2>/dev/null | head -n 1",
+		},
+	}
+
+	if err := execOptions.Validate(); err != nil {
+		return err
+	}
+
+	klog.V(1).Info("Image generation in progress, please wait")
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- execOptions.Run()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for image generation to complete: %w", ctx.Err())
+	case err := <-runErr:
+		if err != nil {
+			var codeExitErr kutils.CodeExitError
+			if !errors.As(err, &codeExitErr) {
+				return o.describeTermination(ctx, err)
 			}
-			return true, nil
-		})
+			return o.describeTermination(ctx, fmt.Errorf("unexpected error code: %w", codeExitErr))
+		}
+	}
+
+	exitCode, err := strconv.Atoi(strings.TrimSpace(w.String()))
+	if err != nil {
+		return fmt.Errorf("unexpected content read from exit_code: %q: %w", w.String(), err)
+	}
+	o.nodeJoinerExitCode = exitCode
+	return nil
+}
+
+// describeTermination enriches a failure from the exit_code exec session with
+// the node-joiner container's last termination reason, e.g. OOMKilled. The
+// cause is returned unchanged when the pod cannot be fetched or reports no
+// last termination state.
+func (o *CreateOptions) describeTermination(ctx context.Context, cause error) error {
+	pod, err := o.Client.CoreV1().Pods(o.nodeJoinerNamespace.GetName()).Get(ctx, o.nodeJoinerPod.GetName(), metav1.GetOptions{})
+	if err != nil || len(pod.Status.ContainerStatuses) == 0 {
+		return cause
+	}
+	last := pod.Status.ContainerStatuses[0].LastTerminationState.Terminated
+	if last == nil {
+		return cause
+	}
+	return fmt.Errorf("node-joiner container terminated (%s): %w", last.Reason, cause)
 }
 
 func (o *CreateOptions) runNodeJoinerPod(ctx context.Context) error {
 	tasks := []func(context.Context) error{
 		o.getNodeJoinerPullSpec,
 		o.createNamespace,
-		o.createServiceAccount,
-		o.createRolesAndBindings,
-		o.createInputConfigMap,
-		o.createPod,
 	}
+	if o.SkipRBAC {
+		tasks = append(tasks, o.useExistingServiceAccount)
+	} else {
+		tasks = append(tasks, o.createServiceAccount, o.createRolesAndBindings)
+	}
+	tasks = append(tasks, o.createInputConfigMap, o.createPod)
 	for _, task := range tasks {
 		if err := task(ctx); err != nil {
 			return err
@@ -375,21 +764,19 @@ func (o *CreateOptions) runNodeJoinerPod(ctx context.Context) error {
 }
 
 func (o *CreateOptions) getNodeJoinerPullSpec(ctx context.Context) error {
-	// Get the current cluster release version.
-	releaseImage, err := o.fetchClusterReleaseImage(ctx)
-	if err != nil {
-		return err
-	}
-
 	// Extract the baremetal-installer image pullspec, since it
 	// provide the node-joiner tool.
 	opts := ocrelease.NewInfoOptions(o.IOStreams)
 	opts.SecurityOptions = o.SecurityOptions
-	release, err := opts.LoadReleaseInfo(releaseImage, false)
+	release, err := opts.LoadReleaseInfo(o.releaseImage, false)
 	if err != nil {
 		return err
 	}
 
+	if err := validateReleaseArchitectures(release.References.GetAnnotations(), o.Architectures, o.releaseImage); err != nil {
+		return err
+	}
+
 	tagName := "baremetal-installer"
 	for _, tag := range release.References.Spec.Tags {
 		if tag.Name == tagName {
@@ -398,7 +785,177 @@ func (o *CreateOptions) getNodeJoinerPullSpec(ctx context.Context) error {
 		}
 	}
 
-	return fmt.Errorf("no image tag %q exists in the release image %s", tagName, releaseImage)
+	return fmt.Errorf("no image tag %q exists in the release image %s", tagName, o.releaseImage)
+}
+
+// releaseArchitectureAnnotation is the annotation OpenShift release image
+// streams carry to report the architecture(s) of the payload they contain:
+// a single Go arch (e.g. "amd64") for a single-arch release, or "multi" for
+// one that bundles every architecture.
+const releaseArchitectureAnnotation = "release.openshift.io/architecture"
+
+// validateReleaseArchitectures checks that every requested --arch is actually
+// covered by the release payload, so a request for an architecture the
+// release doesn't ship fails clearly here instead of deep inside the
+// node-joiner pod (or silently producing nothing).
+func validateReleaseArchitectures(releaseAnnotations map[string]string, architectures []string, releaseImage string) error {
+	releaseArch := releaseAnnotations[releaseArchitectureAnnotation]
+	if releaseArch == "" || releaseArch == "multi" {
+		// A multi-arch release bundles every architecture, and an empty
+		// annotation means an older release that predates it: either way,
+		// there's nothing we can rule out from the release metadata alone.
+		return nil
+	}
+
+	nodeJoinerArch, ok := goArchToNodeJoinerArch[releaseArch]
+	if !ok {
+		return nil
+	}
+
+	var unsupported []string
+	for _, arch := range architectures {
+		if arch != nodeJoinerArch {
+			unsupported = append(unsupported, arch)
+		}
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("release image %s only contains a %s payload: unsupported --arch %s", releaseImage, nodeJoinerArch, strings.Join(unsupported, ", "))
+}
+
+// computeConfigHash derives a stable identifier for the current
+// nodes-config.yaml content and target release image. It is used to label
+// the namespace created for a run, so that a later run with an unchanged
+// configuration can recognize and reuse it instead of starting from scratch.
+func (o *CreateOptions) computeConfigHash(ctx context.Context) error {
+	releaseImage, err := o.fetchClusterReleaseImage(ctx)
+	if err != nil {
+		return err
+	}
+	o.releaseImage = releaseImage
+
+	data, err := fs.ReadFile(o.FSys, nodeJoinerConfigurationFile)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(append(data, []byte(releaseImage)...))
+	// A label value is limited to 63 characters: keep well under that.
+	o.configHash = hex.EncodeToString(sum[:])[:32]
+	return nil
+}
+
+// findReusableRun looks for a namespace from a prior --keep-namespace run
+// whose config-hash label matches the current configuration and whose
+// node-joiner container is still alive and reports a successful exit code,
+// to avoid re-pulling the (multi-GB) node-joiner image and re-generating the
+// artifacts when nothing has changed. It reports whether a reusable run was
+// found and, if so, populates nodeJoinerNamespace and nodeJoinerPod from it.
+func (o *CreateOptions) findReusableRun(ctx context.Context) (bool, error) {
+	if o.Force {
+		return false, nil
+	}
+	if o.DryRunStrategy != kcmdutil.DryRunNone {
+		// A dry run must not depend on, or act on, real state from a prior
+		// run: always take the fresh-run path, which itself returns before
+		// doing anything but rendering/validating.
+		return false, nil
+	}
+
+	namespaces, err := o.Client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", nodeJoinerAppLabel, nodeJoinerAppLabelValue),
+	})
+	if err != nil {
+		return false, fmt.Errorf("cannot list existing node-joiner namespaces: %w", err)
+	}
+
+	var candidate *corev1.Namespace
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if ns.Labels[nodeJoinerConfigHashLabel] != o.configHash {
+			continue
+		}
+		if candidate == nil || ns.CreationTimestamp.After(candidate.CreationTimestamp.Time) {
+			candidate = ns
+		}
+	}
+	if candidate == nil {
+		return false, nil
+	}
+
+	pods, err := o.Client.CoreV1().Pods(candidate.GetName()).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", nodeJoinerAppLabel, nodeJoinerAppLabelValue),
+	})
+	if err != nil {
+		return false, fmt.Errorf("cannot list pods in namespace %s: %w", candidate.GetName(), err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		// With --keep-namespace the node-joiner container is kept alive
+		// (postRunSleepCommand) rather than left to reach PodSucceeded, so
+		// that it can still be exec'd into here to read its exit code.
+		phase, err := containerPhase(pod)
+		if err != nil || phase != podPhaseRunning {
+			continue
+		}
+		exitCode, err := o.readPodExitCode(ctx, candidate.GetName(), pod.GetName())
+		if err != nil {
+			klog.V(1).Infof("Cannot read exit code from pod %s in namespace %s, skipping reuse: %v", pod.GetName(), candidate.GetName(), err)
+			continue
+		}
+		if exitCode != 0 {
+			continue
+		}
+		klog.V(1).Infof("Reusing namespace %s from a prior run with a matching configuration", candidate.GetName())
+		o.nodeJoinerNamespace = candidate
+		o.nodeJoinerPod = pod
+		o.nodeJoinerExitCode = 0
+		return true, nil
+	}
+	return false, nil
+}
+
+// readPodExitCode execs into the still-running node-joiner container to read
+// its exit_code file once, the same file waitForExitCode tails during a
+// fresh run, for findReusableRun to tell a completed-and-succeeded prior run
+// apart from one that's still working or that failed.
+func (o *CreateOptions) readPodExitCode(ctx context.Context, namespace, podName string) (int, error) {
+	w := &bytes.Buffer{}
+	wErr := &bytes.Buffer{}
+
+	execOptions := &exec.ExecOptions{
+		StreamOptions: exec.StreamOptions{
+			Namespace:     namespace,
+			PodName:       podName,
+			ContainerName: nodeJoinerContainer,
+			IOStreams: genericiooptions.IOStreams{
+				In:     nil,
+				Out:    w,
+				ErrOut: wErr,
+			},
+			Stdin: false,
+			Quiet: false,
+		},
+		Executor:  o.remoteExecutor,
+		PodClient: o.Client.CoreV1(),
+		Config:    o.Config,
+		Command:   []string{"/bin/cat", "/assets/exit_code"},
+	}
+
+	if err := execOptions.Validate(); err != nil {
+		return 0, err
+	}
+	if err := execOptions.Run(); err != nil {
+		return 0, fmt.Errorf("%w: %s", err, wErr.String())
+	}
+
+	exitCode, err := strconv.Atoi(strings.TrimSpace(w.String()))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected content read from exit_code: %q: %w", w.String(), err)
+	}
+	return exitCode, nil
 }
 
 func (o *CreateOptions) fetchClusterReleaseImage(ctx context.Context) (string, error) {
@@ -424,24 +981,85 @@ func (o *CreateOptions) createNamespace(ctx context.Context) error {
 	nsNodeJoiner := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "openshift-node-joiner-",
+			Labels: map[string]string{
+				nodeJoinerAppLabel:        nodeJoinerAppLabelValue,
+				nodeJoinerConfigHashLabel: o.configHash,
+			},
 			Annotations: map[string]string{
 				"oc.openshift.io/command":    "oc adm node-image create",
 				"openshift.io/node-selector": "",
 			},
 		},
 	}
+	if o.Namespace != "" {
+		nsNodeJoiner.GenerateName = ""
+		nsNodeJoiner.Name = o.Namespace
+	}
 
-	ns, err := o.Client.CoreV1().Namespaces().Create(ctx, nsNodeJoiner, metav1.CreateOptions{})
-	if err != nil {
+	if err := o.createOrRender(nsNodeJoiner, corev1.SchemeGroupVersion.WithKind("Namespace")); err != nil {
 		return fmt.Errorf("cannot create namespace: %w", err)
 	}
+	if o.DryRunStrategy != kcmdutil.DryRunClient {
+		created, err := o.Client.CoreV1().Namespaces().Create(ctx, nsNodeJoiner, o.createOptions())
+		switch {
+		case err == nil:
+			nsNodeJoiner = created
+		case o.Namespace != "" && kapierrors.IsAlreadyExists(err):
+			// A platform admin may have already applied this namespace
+			// out-of-band from a previous --dry-run=client run.
+			nsNodeJoiner, err = o.Client.CoreV1().Namespaces().Get(ctx, o.Namespace, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("cannot get existing namespace %s: %w", o.Namespace, err)
+			}
+		default:
+			return fmt.Errorf("cannot create namespace: %w", err)
+		}
+	}
 
-	o.nodeJoinerNamespace = ns
+	o.nodeJoinerNamespace = nsNodeJoiner
 	return nil
 }
 
+// createOptions returns the metav1.CreateOptions to use for resources created
+// by runNodeJoinerPod, requesting a server-side dry run when appropriate.
+func (o *CreateOptions) createOptions() metav1.CreateOptions {
+	if o.DryRunStrategy == kcmdutil.DryRunServer {
+		return metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}
+	}
+	return metav1.CreateOptions{}
+}
+
+// createOrRender resolves obj's GenerateName locally and renders it as a YAML
+// manifest to o.IOStreams.Out, instead of letting the API server assign the
+// name, when running with --dry-run=client. It is a no-op otherwise, leaving
+// the actual API call to the caller.
+func (o *CreateOptions) createOrRender(obj metav1.Object, gvk schema.GroupVersionKind) error {
+	if o.DryRunStrategy != kcmdutil.DryRunClient {
+		return nil
+	}
+	if obj.GetName() == "" {
+		obj.SetName(names.SimpleNameGenerator.GenerateName(obj.GetGenerateName()))
+	}
+	runtimeObj, ok := obj.(runtime.Object)
+	if !ok {
+		return fmt.Errorf("%T does not implement runtime.Object", obj)
+	}
+	runtimeObj.GetObjectKind().SetGroupVersionKind(gvk)
+
+	data, err := yaml.Marshal(runtimeObj)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(o.IOStreams.Out, "---\n%s", data)
+	return err
+}
+
+// cleanup deletes the namespace created for this run. It is safe to call
+// more than once (e.g. once explicitly before serveArtifacts and once more
+// via Run's deferred call): it nils out nodeJoinerNamespace once done, so a
+// second call is a no-op.
 func (o *CreateOptions) cleanup(ctx context.Context) {
-	if o.nodeJoinerNamespace == nil {
+	if o.nodeJoinerNamespace == nil || o.DryRunStrategy != kcmdutil.DryRunNone || o.KeepNamespace {
 		return
 	}
 
@@ -449,6 +1067,7 @@ func (o *CreateOptions) cleanup(ctx context.Context) {
 	if err != nil {
 		klog.Errorf("cannot delete namespace %s: %v\n", o.nodeJoinerNamespace.GetName(), err)
 	}
+	o.nodeJoinerNamespace = nil
 }
 
 func (o *CreateOptions) createServiceAccount(ctx context.Context) error {
@@ -461,13 +1080,45 @@ func (o *CreateOptions) createServiceAccount(ctx context.Context) error {
 			Namespace: o.nodeJoinerNamespace.GetName(),
 		},
 	}
+	if o.Namespace != "" {
+		nodeJoinerServiceAccount.GenerateName = ""
+		nodeJoinerServiceAccount.Name = nodeJoinerServiceAccountName
+	}
 
-	sa, err := o.Client.CoreV1().ServiceAccounts(o.nodeJoinerNamespace.GetName()).Create(ctx, nodeJoinerServiceAccount, metav1.CreateOptions{})
-	if err != nil {
+	if err := o.createOrRender(nodeJoinerServiceAccount, corev1.SchemeGroupVersion.WithKind("ServiceAccount")); err != nil {
 		return fmt.Errorf("cannot create service account: %w", err)
 	}
+	if o.DryRunStrategy != kcmdutil.DryRunClient {
+		created, err := o.Client.CoreV1().ServiceAccounts(o.nodeJoinerNamespace.GetName()).Create(ctx, nodeJoinerServiceAccount, o.createOptions())
+		switch {
+		case err == nil:
+			nodeJoinerServiceAccount = created
+		case o.Namespace != "" && kapierrors.IsAlreadyExists(err):
+			nodeJoinerServiceAccount, err = o.Client.CoreV1().ServiceAccounts(o.nodeJoinerNamespace.GetName()).Get(ctx, nodeJoinerServiceAccountName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("cannot get existing service account %s: %w", nodeJoinerServiceAccountName, err)
+			}
+		default:
+			return fmt.Errorf("cannot create service account: %w", err)
+		}
+	}
 
-	o.nodeJoinerServiceAccount = sa
+	o.nodeJoinerServiceAccount = nodeJoinerServiceAccount
+	return nil
+}
+
+// useExistingServiceAccount is used instead of createServiceAccount when
+// --skip-rbac is set: it assumes a platform admin already applied the
+// ServiceAccount (and its ClusterRole/ClusterRoleBinding) out-of-band from a
+// previous --dry-run=client run, and targets the fixed nodeJoinerServiceAccountName
+// ServiceAccount that run rendered.
+func (o *CreateOptions) useExistingServiceAccount(ctx context.Context) error {
+	o.nodeJoinerServiceAccount = &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      nodeJoinerServiceAccountName,
+			Namespace: o.nodeJoinerNamespace.GetName(),
+		},
+	}
 	return nil
 }
 
@@ -516,11 +1167,31 @@ func (o *CreateOptions) createRolesAndBindings(ctx context.Context) error {
 			},
 		},
 	}
-	cr, err := o.Client.RbacV1().ClusterRoles().Create(ctx, nodeJoinerRole, metav1.CreateOptions{})
-	if err != nil {
+	clusterRoleName := ""
+	if o.Namespace != "" {
+		clusterRoleName = "node-joiner-" + o.Namespace
+		nodeJoinerRole.GenerateName = ""
+		nodeJoinerRole.Name = clusterRoleName
+	}
+
+	if err := o.createOrRender(nodeJoinerRole, rbacv1.SchemeGroupVersion.WithKind("ClusterRole")); err != nil {
 		return fmt.Errorf("cannot create role: %w", err)
 	}
-	o.nodeJoinerRole = cr
+	if o.DryRunStrategy != kcmdutil.DryRunClient {
+		created, err := o.Client.RbacV1().ClusterRoles().Create(ctx, nodeJoinerRole, o.createOptions())
+		switch {
+		case err == nil:
+			nodeJoinerRole = created
+		case o.Namespace != "" && kapierrors.IsAlreadyExists(err):
+			nodeJoinerRole, err = o.Client.RbacV1().ClusterRoles().Get(ctx, clusterRoleName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("cannot get existing cluster role %s: %w", clusterRoleName, err)
+			}
+		default:
+			return fmt.Errorf("cannot create role: %w", err)
+		}
+	}
+	o.nodeJoinerRole = nodeJoinerRole
 
 	nodeJoinerRoleBinding := &rbacv1.ClusterRoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
@@ -550,10 +1221,22 @@ func (o *CreateOptions) createRolesAndBindings(ctx context.Context) error {
 			Name:     o.nodeJoinerRole.GetName(),
 		},
 	}
-	_, err = o.Client.RbacV1().ClusterRoleBindings().Create(ctx, nodeJoinerRoleBinding, metav1.CreateOptions{})
-	if err != nil {
+	clusterRoleBindingName := ""
+	if o.Namespace != "" {
+		clusterRoleBindingName = "node-joiner-" + o.Namespace
+		nodeJoinerRoleBinding.GenerateName = ""
+		nodeJoinerRoleBinding.Name = clusterRoleBindingName
+	}
+
+	if err := o.createOrRender(nodeJoinerRoleBinding, rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding")); err != nil {
 		return fmt.Errorf("cannot create role binding: %w", err)
 	}
+	if o.DryRunStrategy != kcmdutil.DryRunClient {
+		_, err := o.Client.RbacV1().ClusterRoleBindings().Create(ctx, nodeJoinerRoleBinding, o.createOptions())
+		if err != nil && !(o.Namespace != "" && kapierrors.IsAlreadyExists(err)) {
+			return fmt.Errorf("cannot create role binding: %w", err)
+		}
+	}
 
 	return nil
 }
@@ -574,21 +1257,49 @@ func (o *CreateOptions) createInputConfigMap(ctx context.Context) error {
 		},
 	}
 
-	_, err = o.Client.CoreV1().ConfigMaps(o.nodeJoinerNamespace.GetName()).Create(ctx, cm, metav1.CreateOptions{})
-	if err != nil {
+	if err := o.createOrRender(cm, corev1.SchemeGroupVersion.WithKind("ConfigMap")); err != nil {
 		return fmt.Errorf("cannot create configmap: %w", err)
 	}
+	if o.DryRunStrategy != kcmdutil.DryRunClient {
+		if _, err := o.Client.CoreV1().ConfigMaps(o.nodeJoinerNamespace.GetName()).Create(ctx, cm, o.createOptions()); err != nil {
+			return fmt.Errorf("cannot create configmap: %w", err)
+		}
+	}
 
 	return nil
 }
 
+// archFlags renders the --arch flags to append to the node-joiner invocation,
+// one per requested target architecture.
+func (o *CreateOptions) archFlags() string {
+	var flags string
+	for _, arch := range o.Architectures {
+		flags += fmt.Sprintf(" --arch=%s", arch)
+	}
+	return flags
+}
+
+// postRunSleepCommand returns the shell command the node-joiner container
+// runs once node-joiner itself exits, to stay alive long enough for this
+// invocation's own artifact copy to complete. With --keep-namespace the
+// namespace (and this pod) are left around for a later findReusableRun to
+// reuse via exec, so the container is kept alive indefinitely instead of
+// exiting into PodSucceeded — where it could no longer be exec'd into —
+// and is only reaped once the namespace itself is cleaned up.
+func (o *CreateOptions) postRunSleepCommand() string {
+	if o.KeepNamespace {
+		return "sleep infinity"
+	}
+	return "sleep 600"
+}
+
 func (o *CreateOptions) createPod(ctx context.Context) error {
 	assetsVolSize := resource.MustParse("4Gi")
 	nodeJoinerPod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "node-joiner-",
 			Labels: map[string]string{
-				"app": "node-joiner",
+				nodeJoinerAppLabel: nodeJoinerAppLabelValue,
 			},
 		},
 		Spec: corev1.PodSpec{
@@ -636,17 +1347,23 @@ func (o *CreateOptions) createPod(ctx context.Context) error {
 					},
 					Command: []string{
 						"/bin/bash", "-c",
-						fmt.Sprintf("cp /config/%s /assets; HOME=/assets node-joiner add-nodes --dir=/assets --log-level=debug; sleep 600", nodeJoinerConfigurationFile),
+						fmt.Sprintf("cp /config/%s /assets; HOME=/assets node-joiner add-nodes --dir=/assets --log-level=debug --format=%s%s; %s", nodeJoinerConfigurationFile, o.Format, o.archFlags(), o.postRunSleepCommand()),
 					},
 				},
 			},
 		},
 	}
-	pod, err := o.Client.CoreV1().Pods(o.nodeJoinerNamespace.GetName()).Create(ctx, nodeJoinerPod, metav1.CreateOptions{})
-	if err != nil {
+	if err := o.createOrRender(nodeJoinerPod, corev1.SchemeGroupVersion.WithKind("Pod")); err != nil {
 		return fmt.Errorf("cannot create pod: %w", err)
 	}
-	o.nodeJoinerPod = pod
+	if o.DryRunStrategy != kcmdutil.DryRunClient {
+		var err error
+		nodeJoinerPod, err = o.Client.CoreV1().Pods(o.nodeJoinerNamespace.GetName()).Create(ctx, nodeJoinerPod, o.createOptions())
+		if err != nil {
+			return fmt.Errorf("cannot create pod: %w", err)
+		}
+	}
+	o.nodeJoinerPod = nodeJoinerPod
 
 	return nil
 }
@@ -0,0 +1,57 @@
+package nodeimage
+
+import "testing"
+
+func TestValidateReleaseArchitectures(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		architectures []string
+		wantErr       bool
+	}{
+		{
+			name:          "single-arch release matching the requested arch",
+			annotations:   map[string]string{releaseArchitectureAnnotation: "amd64"},
+			architectures: []string{"x86_64"},
+			wantErr:       false,
+		},
+		{
+			name:          "single-arch release missing the requested arch",
+			annotations:   map[string]string{releaseArchitectureAnnotation: "amd64"},
+			architectures: []string{"aarch64"},
+			wantErr:       true,
+		},
+		{
+			name:          "single-arch release missing one of several requested arches",
+			annotations:   map[string]string{releaseArchitectureAnnotation: "arm64"},
+			architectures: []string{"aarch64", "x86_64"},
+			wantErr:       true,
+		},
+		{
+			name:          "multi-arch release allows any requested arch",
+			annotations:   map[string]string{releaseArchitectureAnnotation: "multi"},
+			architectures: []string{"x86_64", "s390x"},
+			wantErr:       false,
+		},
+		{
+			name:          "no annotation (older release) is not validated",
+			annotations:   map[string]string{},
+			architectures: []string{"x86_64"},
+			wantErr:       false,
+		},
+		{
+			name:          "unrecognized annotation value is not validated",
+			annotations:   map[string]string{releaseArchitectureAnnotation: "unknown"},
+			architectures: []string{"x86_64"},
+			wantErr:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateReleaseArchitectures(tt.annotations, tt.architectures, "quay.io/openshift-release-dev/ocp-release:4.99.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateReleaseArchitectures() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
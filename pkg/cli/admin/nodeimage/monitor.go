@@ -0,0 +1,425 @@
+package nodeimage
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	kapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericiooptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	kcmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/templates"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	monitorLong = templates.LongDesc(`
+		Monitor the nodes booted from a previously generated ISO image as they
+		join the target cluster.
+
+		This command reads the same nodes-config.yaml file used by "oc adm
+		node-image create" and watches the cluster for the certificate signing
+		requests and Node objects that correspond to the hosts it describes,
+		reporting the progress of each host as it joins.
+
+		By default, pending kubelet-serving and node-bootstrapper CSRs raised by
+		the monitored hosts are automatically approved.
+	`)
+
+	monitorExample = templates.Examples(`
+		# Watch the nodes defined in nodes-config.yaml as they join the cluster
+		  oc adm node-image monitor
+
+		# Only report progress, without auto-approving the node CSRs
+		  oc adm node-image monitor --auto-approve=false
+
+		# Emit machine-readable progress, for use in a CI pipeline
+		  oc adm node-image monitor -o json
+	`)
+)
+
+// hostPhase represents the possible states a monitored host goes through
+// while joining the cluster.
+type hostPhase string
+
+const (
+	hostPhasePending        hostPhase = "CSRPending"
+	hostPhaseCSRApproved    hostPhase = "CSRApproved"
+	hostPhaseNodeRegistered hostPhase = "NodeRegistered"
+	hostPhaseNodeReady      hostPhase = "NodeReady"
+)
+
+// nodeJoinerConfig mirrors the subset of nodes-config.yaml that is relevant
+// to identify a host's CSRs and Node object.
+type nodeJoinerConfig struct {
+	Hosts []nodeJoinerConfigHost `json:"hosts"`
+}
+
+type nodeJoinerConfigHost struct {
+	Hostname       string `json:"hostname"`
+	BootMACAddress string `json:"bootMACAddress"`
+}
+
+// hostStatus is the progress of a single monitored host, and is also the
+// shape emitted when -o json|yaml is requested.
+type hostStatus struct {
+	Hostname string    `json:"hostname"`
+	Phase    hostPhase `json:"phase"`
+	NodeName string    `json:"nodeName,omitempty"`
+}
+
+// NewMonitor creates the command for watching nodes joining the cluster
+// after booting from the generated ISO.
+func NewMonitor(f kcmdutil.Factory, streams genericiooptions.IOStreams) *cobra.Command {
+	o := NewMonitorOptions(streams)
+	cmd := &cobra.Command{
+		Use:     "monitor",
+		Short:   "Monitor the nodes joining the target cluster after the ISO boot",
+		Long:    monitorLong,
+		Example: monitorExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			kcmdutil.CheckErr(o.Complete(f, cmd, args))
+			kcmdutil.CheckErr(o.Validate())
+			kcmdutil.CheckErr(o.Run())
+		},
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
+
+// NewMonitorOptions creates the options for the monitor command.
+func NewMonitorOptions(streams genericiooptions.IOStreams) *MonitorOptions {
+	return &MonitorOptions{
+		IOStreams:   streams,
+		Timeout:     30 * time.Minute,
+		AutoApprove: true,
+	}
+}
+
+type MonitorOptions struct {
+	genericiooptions.IOStreams
+
+	Config *rest.Config
+	Client kubernetes.Interface
+	FSys   fs.FS
+
+	// AssetsDir is used to locate the nodes-config.yaml file describing the
+	// hosts to monitor.
+	AssetsDir string
+	// Timeout bounds how long the command waits for every monitored host to
+	// become Ready.
+	Timeout time.Duration
+	// AutoApprove controls whether pending CSRs raised by the monitored hosts
+	// are approved automatically.
+	AutoApprove bool
+	// Output selects a machine-readable rendering of the per-host progress,
+	// one of "json" or "yaml". Left empty, progress is printed as plain text.
+	Output string
+
+	hosts []nodeJoinerConfigHost
+}
+
+// AddFlags defined the required command flags.
+func (o *MonitorOptions) AddFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	flags.StringVar(&o.AssetsDir, "dir", o.AssetsDir, "The path containing the configuration file used to identify the hosts to monitor.")
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout, "Time to wait for all the monitored hosts to become Ready.")
+	flags.BoolVar(&o.AutoApprove, "auto-approve", o.AutoApprove, "Automatically approve the pending CSRs raised by the monitored hosts.")
+	flags.StringVarP(&o.Output, "output", "o", o.Output, "Output format for the per-host progress. One of: json|yaml.")
+}
+
+// Complete completes the required options for the monitor command.
+func (o *MonitorOptions) Complete(f kcmdutil.Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	if o.Config, err = f.ToRESTConfig(); err != nil {
+		return err
+	}
+	if o.Client, err = kubernetes.NewForConfig(o.Config); err != nil {
+		return err
+	}
+
+	if o.AssetsDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		o.AssetsDir = cwd
+	}
+	o.FSys = os.DirFS(o.AssetsDir)
+
+	return nil
+}
+
+// Validate returns validation errors related to the monitor command.
+func (o *MonitorOptions) Validate() error {
+	if o.Output != "" && o.Output != "json" && o.Output != "yaml" {
+		return fmt.Errorf("invalid output format %q: only json and yaml are supported", o.Output)
+	}
+
+	data, err := fs.ReadFile(o.FSys, nodeJoinerConfigurationFile)
+	if err != nil {
+		return err
+	}
+	var cfg nodeJoinerConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("config file %s is not valid: %w", nodeJoinerConfigurationFile, err)
+	}
+	if len(cfg.Hosts) == 0 {
+		return fmt.Errorf("config file %s does not define any host", nodeJoinerConfigurationFile)
+	}
+	o.hosts = cfg.Hosts
+
+	return nil
+}
+
+// Run watches the cluster for the CSRs and Node objects raised by the
+// monitored hosts, auto-approving them when requested, until every host is
+// Ready or the timeout elapses.
+//
+// CSRs and Nodes are watched concurrently, for the entire run, rather than
+// waiting for every host's first CSR to be approved before watching Nodes:
+// a joining host raises a second (kubelet-serving) CSR only after its Node
+// object registers, and that second CSR needs to keep being caught and
+// approved well after the host's first CSR went through.
+func (o *MonitorOptions) Run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout)
+	defer cancel()
+
+	statuses := make(map[string]*hostStatus, len(o.hosts))
+	for _, h := range o.hosts {
+		statuses[h.Hostname] = &hostStatus{Hostname: h.Hostname, Phase: hostPhasePending}
+	}
+
+	csrWatch, err := o.Client.CertificatesV1().CertificateSigningRequests().Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	defer csrWatch.Stop()
+
+	nodeWatch, err := o.Client.CoreV1().Nodes().Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	defer nodeWatch.Stop()
+
+	for {
+		if o.allAtLeast(statuses, hostPhaseNodeReady) {
+			o.printProgress(statuses)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for all monitored hosts to become Ready: %w", ctx.Err())
+		case event, ok := <-csrWatch.ResultChan():
+			if !ok {
+				return fmt.Errorf("CSR watch closed unexpectedly")
+			}
+			o.handleCSREvent(ctx, event, statuses)
+		case event, ok := <-nodeWatch.ResultChan():
+			if !ok {
+				return fmt.Errorf("node watch closed unexpectedly")
+			}
+			o.handleNodeEvent(event, statuses)
+		}
+	}
+}
+
+// handleCSREvent approves a pending CSR raised by a monitored host. It is
+// invoked for both the node-bootstrapper (client) CSR a host raises before
+// registering and the kubelet-serving CSR it raises afterwards, so it does
+// not gate on the host's current phase.
+func (o *MonitorOptions) handleCSREvent(ctx context.Context, event watch.Event, statuses map[string]*hostStatus) {
+	csr, ok := event.Object.(*certificatesv1.CertificateSigningRequest)
+	if !ok || event.Type == watch.Deleted {
+		return
+	}
+	if !isKubeletCSR(csr) {
+		return
+	}
+	hostname, ok := hostnameFromCSR(csr)
+	if !ok {
+		return
+	}
+	status, known := statuses[hostname]
+	if !known {
+		return
+	}
+	if isApproved(csr) {
+		if status.Phase == hostPhasePending {
+			status.Phase = hostPhaseCSRApproved
+			o.printProgress(statuses)
+		}
+		return
+	}
+	if !o.AutoApprove {
+		return
+	}
+	if err := o.approveCSR(ctx, csr); err != nil {
+		klog.Errorf("cannot approve CSR %s: %v", csr.GetName(), err)
+		return
+	}
+	if status.Phase == hostPhasePending {
+		status.Phase = hostPhaseCSRApproved
+		o.printProgress(statuses)
+	}
+}
+
+// handleNodeEvent tracks a monitored host as its Node object registers and
+// then becomes Ready.
+func (o *MonitorOptions) handleNodeEvent(event watch.Event, statuses map[string]*hostStatus) {
+	node, ok := event.Object.(*corev1.Node)
+	if !ok || event.Type == watch.Deleted {
+		return
+	}
+	status, known := statuses[node.GetName()]
+	if !known {
+		return
+	}
+	status.NodeName = node.GetName()
+	if status.Phase == hostPhaseCSRApproved {
+		status.Phase = hostPhaseNodeRegistered
+		o.printProgress(statuses)
+	}
+	if isNodeReady(node) {
+		status.Phase = hostPhaseNodeReady
+		o.printProgress(statuses)
+	}
+}
+
+func (o *MonitorOptions) approveCSR(ctx context.Context, csr *certificatesv1.CertificateSigningRequest) error {
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:    certificatesv1.CertificateApproved,
+		Status:  corev1.ConditionTrue,
+		Reason:  "NodeImageMonitorApprove",
+		Message: "This CSR was approved by oc adm node-image monitor",
+	})
+	_, err := o.Client.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.GetName(), csr, metav1.UpdateOptions{})
+	if kapierrors.IsConflict(err) {
+		// The CSR was updated concurrently (e.g. already approved by someone
+		// else); not a fatal error for our purposes.
+		return nil
+	}
+	return err
+}
+
+func (o *MonitorOptions) allAtLeast(statuses map[string]*hostStatus, phase hostPhase) bool {
+	rank := map[hostPhase]int{
+		hostPhasePending:        0,
+		hostPhaseCSRApproved:    1,
+		hostPhaseNodeRegistered: 2,
+		hostPhaseNodeReady:      3,
+	}
+	for _, status := range statuses {
+		if rank[status.Phase] < rank[phase] {
+			return false
+		}
+	}
+	return true
+}
+
+// printProgress renders the current per-host status, either as plain text
+// progress lines or, when an Output format was requested, as a single
+// json/yaml document suitable for scripting.
+func (o *MonitorOptions) printProgress(statuses map[string]*hostStatus) {
+	ordered := make([]hostStatus, 0, len(statuses))
+	for _, status := range statuses {
+		ordered = append(ordered, *status)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Hostname < ordered[j].Hostname })
+
+	switch o.Output {
+	case "json":
+		data, err := json.MarshalIndent(ordered, "", "  ")
+		if err != nil {
+			klog.Errorf("cannot render progress as json: %v", err)
+			return
+		}
+		fmt.Fprintln(o.IOStreams.Out, string(data))
+	case "yaml":
+		data, err := yaml.Marshal(ordered)
+		if err != nil {
+			klog.Errorf("cannot render progress as yaml: %v", err)
+			return
+		}
+		fmt.Fprint(o.IOStreams.Out, string(data))
+	default:
+		for _, status := range ordered {
+			fmt.Fprintf(o.IOStreams.Out, "%s: %s\n", status.Hostname, status.Phase)
+		}
+	}
+}
+
+// nodeUserPrefix is prepended to a node's hostname in the "system:node:<hostname>"
+// username kubelet requests its certificates under, both as spec.Username on
+// the CSR and as the Subject CommonName of the x509 request it carries.
+const nodeUserPrefix = "system:node:"
+
+// hostnameFromCSR recovers the hostname a kubelet-issued CSR was raised for,
+// by parsing the Subject CommonName of the x509 certificate request it
+// carries. It reports false when the request cannot be parsed or its
+// CommonName lacks the system:node: prefix; it does not check the signer,
+// see isKubeletCSR.
+func hostnameFromCSR(csr *certificatesv1.CertificateSigningRequest) (string, bool) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil {
+		return "", false
+	}
+	req, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return "", false
+	}
+	if !strings.HasPrefix(req.Subject.CommonName, nodeUserPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(req.Subject.CommonName, nodeUserPrefix), true
+}
+
+// isKubeletCSR reports whether csr was raised by the kubelet bootstrap flow
+// this command is meant to auto-approve: the node-bootstrapper (client) CSR
+// or the kubelet-serving CSR. A CN matching system:node:<hostname> is not
+// enough on its own to prove that, since it's an arbitrary string a CSR's
+// requester can set regardless of what signer will act on it.
+func isKubeletCSR(csr *certificatesv1.CertificateSigningRequest) bool {
+	switch csr.Spec.SignerName {
+	case certificatesv1.KubeAPIServerClientKubeletSignerName, certificatesv1.KubeletServingSignerName:
+		return true
+	default:
+		return false
+	}
+}
+
+func isApproved(csr *certificatesv1.CertificateSigningRequest) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == certificatesv1.CertificateApproved && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
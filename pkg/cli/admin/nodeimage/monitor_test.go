@@ -0,0 +1,174 @@
+package nodeimage
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newCSRPEM builds a PEM-encoded x509 CSR with the given Subject CommonName,
+// the same shape hostnameFromCSR has to parse out of csr.Spec.Request.
+func newCSRPEM(t *testing.T, commonName string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatalf("unexpected error creating CSR: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestHostnameFromCSR(t *testing.T) {
+	tests := []struct {
+		name         string
+		request      []byte
+		wantHostname string
+		wantOK       bool
+	}{
+		{
+			name:         "node client CSR",
+			request:      newCSRPEM(t, "system:node:host1.example.com"),
+			wantHostname: "host1.example.com",
+			wantOK:       true,
+		},
+		{
+			name:    "CN without the node user prefix",
+			request: newCSRPEM(t, "someone-else"),
+			wantOK:  false,
+		},
+		{
+			name:    "not PEM",
+			request: []byte("not a pem block"),
+			wantOK:  false,
+		},
+		{
+			name:    "PEM block that isn't a valid CSR",
+			request: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: []byte("garbage")}),
+			wantOK:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csr := &certificatesv1.CertificateSigningRequest{
+				Spec: certificatesv1.CertificateSigningRequestSpec{Request: tt.request},
+			}
+			hostname, ok := hostnameFromCSR(csr)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && hostname != tt.wantHostname {
+				t.Fatalf("hostname = %q, want %q", hostname, tt.wantHostname)
+			}
+		})
+	}
+}
+
+func TestIsKubeletCSR(t *testing.T) {
+	tests := []struct {
+		name       string
+		signerName string
+		want       bool
+	}{
+		{"node client signer", certificatesv1.KubeAPIServerClientKubeletSignerName, true},
+		{"kubelet serving signer", certificatesv1.KubeletServingSignerName, true},
+		{"generic api client signer", certificatesv1.KubeAPIServerClientSignerName, false},
+		{"unknown signer", "example.com/spoofed-signer", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csr := &certificatesv1.CertificateSigningRequest{
+				Spec: certificatesv1.CertificateSigningRequestSpec{SignerName: tt.signerName},
+			}
+			if got := isKubeletCSR(csr); got != tt.want {
+				t.Fatalf("isKubeletCSR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsNodeReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []corev1.NodeCondition
+		want       bool
+	}{
+		{
+			name:       "ready",
+			conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			want:       true,
+		},
+		{
+			name:       "not ready",
+			conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}},
+			want:       false,
+		},
+		{
+			name:       "no ready condition reported yet",
+			conditions: []corev1.NodeCondition{{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse}},
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &corev1.Node{Status: corev1.NodeStatus{Conditions: tt.conditions}}
+			if got := isNodeReady(node); got != tt.want {
+				t.Fatalf("isNodeReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllAtLeast(t *testing.T) {
+	o := &MonitorOptions{}
+	tests := []struct {
+		name     string
+		statuses map[string]*hostStatus
+		phase    hostPhase
+		want     bool
+	}{
+		{
+			name: "all past the requested phase",
+			statuses: map[string]*hostStatus{
+				"host1": {Phase: hostPhaseNodeReady},
+				"host2": {Phase: hostPhaseNodeRegistered},
+			},
+			phase: hostPhaseCSRApproved,
+			want:  true,
+		},
+		{
+			name: "one host still behind",
+			statuses: map[string]*hostStatus{
+				"host1": {Phase: hostPhaseNodeReady},
+				"host2": {Phase: hostPhasePending},
+			},
+			phase: hostPhaseNodeReady,
+			want:  false,
+		},
+		{
+			name:     "no hosts",
+			statuses: map[string]*hostStatus{},
+			phase:    hostPhaseNodeReady,
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := o.allAtLeast(tt.statuses, tt.phase); got != tt.want {
+				t.Fatalf("allAtLeast() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}